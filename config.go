@@ -0,0 +1,136 @@
+package reqip
+
+import (
+	"net"
+	"net/http"
+)
+
+// UnlimitedForwardLimit : Pass as Config.ForwardLimit to skip trusted hops
+// with no cap. Only use this when every possible proxy in the chain is
+// guaranteed to be trusted, since an uncapped walk will happily skip past
+// an attacker-forged run of addresses that happen to fall inside a trusted
+// CIDR (e.g. an internal range reused by the attacker-controlled hop).
+const UnlimitedForwardLimit = -1
+
+// DefaultForwardLimit is used whenever Config.ForwardLimit is left at its
+// zero value, so a Config{TrustedProxies: ...} literal is safe by default
+// instead of silently walking the whole chain.
+const DefaultForwardLimit = 1
+
+// Config : Options controlling how GetClientIPWithConfig trusts proxy-supplied
+// headers. Zero value behaves like an open proxy (nothing trusted).
+type Config struct {
+	// TrustedProxies is a list of CIDR ranges (e.g. "10.0.0.0/8") that are
+	// allowed to report a client IP via X-Forwarded-For. r.RemoteAddr must
+	// fall inside one of these ranges for header-derived IPs to be trusted
+	// at all.
+	TrustedProxies []string
+
+	// ForwardLimit caps how many trusted hops are skipped when walking
+	// X-Forwarded-For from right to left. The zero value means
+	// DefaultForwardLimit, not unlimited - set UnlimitedForwardLimit
+	// explicitly if that's really what you want.
+	ForwardLimit int
+}
+
+// resolvedForwardLimit : Turn the zero value of Config.ForwardLimit into
+// DefaultForwardLimit, leaving any explicit value (including
+// UnlimitedForwardLimit) untouched.
+func resolvedForwardLimit(limit int) int {
+	if limit == 0 {
+		return DefaultForwardLimit
+	}
+	return limit
+}
+
+// parseTrustedProxies : Turn the configured CIDR strings into *net.IPNet,
+// silently skipping anything that fails to parse.
+func parseTrustedProxies(cidrs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// isTrustedProxy : Report whether ip falls inside any of the given networks.
+func isTrustedProxy(ip string, nets []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddrIP : Extract the bare IP from r.RemoteAddr, treating the
+// unix-socket form "@" as loopback.
+func remoteAddrIP(remoteAddr string) string {
+	if remoteAddr == "@" {
+		return "127.0.0.1"
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// GetClientIPWithConfig : Resolve the client IP from X-Forwarded-For and
+// r.RemoteAddr only; the other headers GetClientIP reads are not consulted.
+// X-Forwarded-For is only trusted when r.RemoteAddr matches one of
+// Config.TrustedProxies, otherwise r.RemoteAddr's IP is returned. The header
+// is then walked from right to left,
+// skipping up to Config.ForwardLimit trusted proxies, and the first
+// untrusted IP encountered is returned as the real client - matching the
+// behavior of chi-middleware/proxy's ForwardedHeaders. This defends against
+// spoofing via a client-supplied X-Forwarded-For value, which the left-most
+// logic in GetClientIP cannot.
+func GetClientIPWithConfig(r *http.Request, cfg Config) string {
+	trusted := parseTrustedProxies(cfg.TrustedProxies)
+	remote := remoteAddrIP(r.RemoteAddr)
+
+	if !isTrustedProxy(remote, trusted) {
+		if isIP(remote) {
+			return remote
+		}
+		return ""
+	}
+
+	if ip := xffTrustedWalk(r.Header.Values("x-forwarded-for"), trusted, resolvedForwardLimit(cfg.ForwardLimit)); ip != "" {
+		return ip
+	}
+
+	if isIP(remote) {
+		return remote
+	}
+
+	return ""
+}
+
+// xffTrustedWalk : Walk X-Forwarded-For values from right to left, skipping
+// up to limit trusted proxies (limit < 0 means unlimited), and return the
+// first untrusted IP encountered.
+func xffTrustedWalk(values []string, trusted []*net.IPNet, limit int) string {
+	ips := splitForwardedFor(values)
+
+	skipped := 0
+	for i := len(ips) - 1; i >= 0; i-- {
+		ip := ips[i]
+		if !isIP(ip) {
+			continue
+		}
+		if isTrustedProxy(ip, trusted) && (limit < 0 || skipped < limit) {
+			skipped++
+			continue
+		}
+		return ip
+	}
+
+	return ""
+}
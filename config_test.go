@@ -0,0 +1,85 @@
+package reqip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func trustedRequest(remoteAddr, xff string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remoteAddr
+	if xff != "" {
+		r.Header.Set("X-Forwarded-For", xff)
+	}
+	return r
+}
+
+func TestGetClientIPWithConfigDefaultForwardLimit(t *testing.T) {
+	// Zero-value Config.ForwardLimit must behave like DefaultForwardLimit
+	// (1), not unlimited - a run of forged addresses that happen to fall
+	// inside the trusted CIDR must not all be skipped.
+	r := trustedRequest("10.0.0.3:1234", "9.9.9.9, 10.0.0.2, 10.0.0.3")
+
+	got := GetClientIPWithConfig(r, Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	if got != "10.0.0.2" {
+		t.Errorf("GetClientIPWithConfig() = %q, want 10.0.0.2 (only one trusted hop skipped)", got)
+	}
+}
+
+func TestGetClientIPWithConfigUnlimitedForwardLimit(t *testing.T) {
+	r := trustedRequest("10.0.0.3:1234", "9.9.9.9, 10.0.0.2, 10.0.0.3")
+
+	got := GetClientIPWithConfig(r, Config{
+		TrustedProxies: []string{"10.0.0.0/8"},
+		ForwardLimit:   UnlimitedForwardLimit,
+	})
+	if got != "9.9.9.9" {
+		t.Errorf("GetClientIPWithConfig() = %q, want 9.9.9.9 with unlimited skipping", got)
+	}
+}
+
+func TestGetClientIPWithConfigUntrustedRemote(t *testing.T) {
+	// RemoteAddr isn't a trusted proxy, so the header must be ignored
+	// entirely rather than trusted at face value.
+	r := trustedRequest("203.0.113.9:1234", "9.9.9.9")
+
+	got := GetClientIPWithConfig(r, Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	if got != "203.0.113.9" {
+		t.Errorf("GetClientIPWithConfig() = %q, want 203.0.113.9 (RemoteAddr, headers untrusted)", got)
+	}
+}
+
+func TestGetClientIPWithConfigUnixSocketRemote(t *testing.T) {
+	r := trustedRequest("@", "9.9.9.9")
+	r.RemoteAddr = "@"
+
+	got := GetClientIPWithConfig(r, Config{TrustedProxies: []string{"127.0.0.0/8"}})
+	if got != "9.9.9.9" {
+		t.Errorf("GetClientIPWithConfig() = %q, want 9.9.9.9 treating @ as loopback", got)
+	}
+}
+
+func TestXFFTrustedWalk(t *testing.T) {
+	trusted := parseTrustedProxies([]string{"10.0.0.0/8"})
+
+	cases := []struct {
+		name  string
+		xff   string
+		limit int
+		want  string
+	}{
+		{"skip one hop", "9.9.9.9, 10.0.0.2, 10.0.0.3", 1, "10.0.0.2"},
+		{"unlimited skips all trusted", "9.9.9.9, 10.0.0.2, 10.0.0.3", UnlimitedForwardLimit, "9.9.9.9"},
+		{"no trusted hops to skip", "8.8.8.8", 1, "8.8.8.8"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := xffTrustedWalk([]string{tc.xff}, trusted, tc.limit)
+			if got != tc.want {
+				t.Errorf("xffTrustedWalk(%q, limit=%d) = %q, want %q", tc.xff, tc.limit, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package reqip
+
+import "strings"
+
+// ForwardedElement : A single hop parsed out of an RFC 7239 Forwarded
+// header, e.g. `for=192.0.2.60;proto=http;by=203.0.113.43`.
+type ForwardedElement struct {
+	For   string
+	By    string
+	Host  string
+	Proto string
+}
+
+// ParseForwarded : Parse an RFC 7239 `Forwarded` header into its list of
+// hops so callers can read `proto` and `host` in addition to `for`/`by`.
+// Quoted values and the brackets around IPv6 literals are stripped, but
+// obfuscated identifiers (`_hidden`) and `unknown` are returned as-is -
+// callers that only want a usable client IP should use GetClientIP, which
+// skips them.
+func ParseForwarded(header string) []ForwardedElement {
+	if header == "" {
+		return nil
+	}
+
+	var elements []ForwardedElement
+
+	for _, part := range strings.Split(header, ",") {
+		var el ForwardedElement
+
+		for _, pair := range strings.Split(part, ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			value := unquoteForwardedValue(strings.TrimSpace(kv[1]))
+
+			switch key {
+			case "for":
+				// for/by are IP literals (optionally with a port); strip
+				// the port the same way for every address family so
+				// callers can compare For/By across hops consistently.
+				el.For = stripPort(value)
+			case "by":
+				el.By = stripPort(value)
+			case "host":
+				el.Host = value
+			case "proto":
+				el.Proto = value
+			}
+		}
+
+		elements = append(elements, el)
+	}
+
+	return elements
+}
+
+// unquoteForwardedValue : Strip the double quotes RFC 7239 requires around
+// values containing a colon or semicolon (e.g. `"[2001:db8::1]:4711"`).
+// Port/bracket stripping for for/by is applied by the caller via stripPort.
+func unquoteForwardedValue(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// isObfuscatedOrUnknown : Report whether a `for`/`by` identifier opts out of
+// revealing a real IP, per RFC 7239 section 6.3.
+func isObfuscatedOrUnknown(identifier string) bool {
+	return identifier == "" || identifier == "unknown" || strings.HasPrefix(identifier, "_")
+}
+
+// getClientIPFromForwarded : Walk a Forwarded header's hops in order and
+// return the first `for` identifier that is a concrete IP, skipping
+// obfuscated and unknown identifiers. values is the result of
+// r.Header.Values("forwarded") - a proxy chain may legally repeat the
+// header, one line per hop, so all of them are joined with commas before
+// parsing, the same way splitForwardedFor joins repeated X-Forwarded-For
+// values.
+func getClientIPFromForwarded(values []string) string {
+	header := strings.Join(values, ",")
+
+	for _, el := range ParseForwarded(header) {
+		if isObfuscatedOrUnknown(el.For) {
+			continue
+		}
+
+		if isIP(el.For) {
+			return el.For
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,55 @@
+package reqip
+
+import "testing"
+
+func TestParseForwarded(t *testing.T) {
+	header := `for=192.0.2.60:80;proto=http;by=203.0.113.43, for="[2001:db8::1]:4711";host=example.com:8080`
+
+	got := ParseForwarded(header)
+	if len(got) != 2 {
+		t.Fatalf("ParseForwarded() returned %d elements, want 2", len(got))
+	}
+
+	first := got[0]
+	if first.For != "192.0.2.60" || first.By != "203.0.113.43" || first.Proto != "http" {
+		t.Errorf("first element = %+v, want For=192.0.2.60 By=203.0.113.43 Proto=http", first)
+	}
+
+	second := got[1]
+	if second.For != "2001:db8::1" {
+		t.Errorf("second element For = %q, want 2001:db8::1 (port stripped consistently with IPv4)", second.For)
+	}
+	if second.Host != "example.com:8080" {
+		t.Errorf("second element Host = %q, want example.com:8080 (host port must not be stripped)", second.Host)
+	}
+}
+
+func TestParseForwardedObfuscatedAndUnknown(t *testing.T) {
+	header := `for=_mystery, for=unknown, for=203.0.113.5`
+
+	got := ParseForwarded(header)
+	if len(got) != 3 {
+		t.Fatalf("ParseForwarded() returned %d elements, want 3", len(got))
+	}
+	if got[0].For != "_mystery" || got[1].For != "unknown" {
+		t.Errorf("obfuscated/unknown identifiers must be returned as-is, got %+v", got[:2])
+	}
+}
+
+func TestGetClientIPFromForwardedSkipsObfuscated(t *testing.T) {
+	header := "for=_hidden, for=unknown, for=203.0.113.5"
+
+	if got := getClientIPFromForwarded([]string{header}); got != "203.0.113.5" {
+		t.Errorf("getClientIPFromForwarded() = %q, want 203.0.113.5", got)
+	}
+}
+
+func TestGetClientIPFromForwardedJoinsRepeatedHeaders(t *testing.T) {
+	// Each proxy hop may append its own Forwarded header line rather than
+	// appending to a single line; both lines must be considered.
+	values := []string{"for=_hidden", "for=203.0.113.7"}
+
+	if got := getClientIPFromForwarded(values); got != "203.0.113.7" {
+		t.Errorf("getClientIPFromForwarded(%v) = %q, want 203.0.113.7", values, got)
+	}
+}
@@ -0,0 +1,105 @@
+package reqip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// splitForwardedFor : Split one or more X-Forwarded-For header values into
+// their individual IP tokens. Per RFC 7239 guidance a header may be sent
+// more than once, so callers pass the result of r.Header.Values(...) and
+// this joins them with commas before splitting - matching how most proxies
+// concatenate repeated values. Tokens are trimmed and, where present, their
+// port is stripped without mangling bare or bracketed IPv6 addresses.
+func splitForwardedFor(values []string) []string {
+	header := strings.Join(values, ",")
+	if header == "" {
+		return nil
+	}
+
+	parts := strings.Split(header, ",")
+
+	var ips []string
+	for _, part := range parts {
+		token := strings.TrimSpace(part)
+		if token == "" {
+			continue
+		}
+		ips = append(ips, stripPort(token))
+	}
+
+	return ips
+}
+
+// stripPort : Remove a trailing ":port" from host, being careful not to
+// confuse it with the colons inside a bare IPv6 address. Bracketed IPv6
+// ("[::1]:443" or "[::1]") is unwrapped either way.
+func stripPort(host string) string {
+	if strings.HasPrefix(host, "[") {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			return h
+		}
+		return strings.Trim(host, "[]")
+	}
+
+	// A bare IPv6 address has more than one colon; "ip:port" has exactly one.
+	if strings.Count(host, ":") == 1 {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			return h
+		}
+	}
+
+	return host
+}
+
+// getClientIPFromXForwardedFor : Parse x-forwarded-for header values and
+// return the left-most address that parses as an IP.
+//
+// Sometimes IP addresses in this header can be 'unknown' (http://stackoverflow.com/a/11285650).
+// Therefore taking the left-most IP address that is not unknown.
+// A Squid configuration directive can also set the value to "unknown" (http://www.squid-cache.org/Doc/config/forwarded_for/).
+func getClientIPFromXForwardedFor(values []string) string {
+	ips := splitForwardedFor(values)
+
+	for _, ip := range ips {
+		if isIP(ip) {
+			return ip
+		}
+	}
+
+	return ""
+}
+
+// GetClientIPLeftmost : Return the left-most IP in X-Forwarded-For, i.e. the
+// address the originating client claims to be. This value is attacker
+// controlled unless every hop in front of it is trusted - prefer
+// GetClientIPRightmost or GetClientIPWithConfig when reqip sits behind a
+// known reverse proxy.
+func GetClientIPLeftmost(r *http.Request) string {
+	return getClientIPFromXForwardedFor(r.Header.Values("x-forwarded-for"))
+}
+
+// getClientIPFromXForwardedForRightmost : Parse x-forwarded-for header
+// values and return the right-most address that parses as an IP, i.e. the
+// one appended by whichever proxy is closest to us.
+func getClientIPFromXForwardedForRightmost(values []string) string {
+	ips := splitForwardedFor(values)
+
+	for i := len(ips) - 1; i >= 0; i-- {
+		if isIP(ips[i]) {
+			return ips[i]
+		}
+	}
+
+	return ""
+}
+
+// GetClientIPRightmost : Return the right-most IP in X-Forwarded-For, i.e.
+// the address of whichever proxy most recently appended to the chain. This
+// is safe to trust only when that proxy is the one reqip is listening
+// behind; to skip a known chain of trusted proxies and find the first
+// untrusted hop, use GetClientIPWithConfig instead.
+func GetClientIPRightmost(r *http.Request) string {
+	return getClientIPFromXForwardedForRightmost(r.Header.Values("x-forwarded-for"))
+}
@@ -0,0 +1,72 @@
+package reqip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func xffRequest(header string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		r.Header.Set("X-Forwarded-For", header)
+	}
+	return r
+}
+
+func TestSplitForwardedFor(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"comma no space", []string{"1.2.3.4,5.6.7.8"}, []string{"1.2.3.4", "5.6.7.8"}},
+		{"comma with space", []string{"1.2.3.4, 5.6.7.8"}, []string{"1.2.3.4", "5.6.7.8"}},
+		{"ipv4 with port", []string{"1.2.3.4:8080"}, []string{"1.2.3.4"}},
+		{"bare ipv6", []string{"2001:db8::1"}, []string{"2001:db8::1"}},
+		{"bracketed ipv6 with port", []string{"[2001:db8::1]:443"}, []string{"2001:db8::1"}},
+		{"multiple header values joined", []string{"1.1.1.1", "2.2.2.2"}, []string{"1.1.1.1", "2.2.2.2"}},
+		{"empty", nil, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := splitForwardedFor(tc.values)
+			if len(got) != len(tc.want) {
+				t.Fatalf("splitForwardedFor(%v) = %v, want %v", tc.values, got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("splitForwardedFor(%v) = %v, want %v", tc.values, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetClientIPLeftmostRightmost(t *testing.T) {
+	r := xffRequest("9.9.9.9, 10.0.0.2, 10.0.0.3")
+
+	if got := GetClientIPLeftmost(r); got != "9.9.9.9" {
+		t.Errorf("GetClientIPLeftmost = %q, want 9.9.9.9", got)
+	}
+	if got := GetClientIPRightmost(r); got != "10.0.0.3" {
+		t.Errorf("GetClientIPRightmost = %q, want 10.0.0.3", got)
+	}
+}
+
+func TestGetClientIPLeftmostZoned(t *testing.T) {
+	r := xffRequest("[fe80::1%eth0]:443")
+
+	if got := GetClientIPLeftmost(r); got != "fe80::1%eth0" {
+		t.Errorf("GetClientIPLeftmost = %q, want fe80::1%%eth0", got)
+	}
+}
+
+func TestGetClientIPLeftmostSkipsUnknown(t *testing.T) {
+	r := xffRequest("unknown, 8.8.8.8")
+
+	if got := GetClientIPLeftmost(r); got != "8.8.8.8" {
+		t.Errorf("GetClientIPLeftmost = %q, want 8.8.8.8", got)
+	}
+}
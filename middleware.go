@@ -0,0 +1,147 @@
+package reqip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Option : Configures the handler returned by Middleware.
+type Option func(*middlewareConfig)
+
+type middlewareConfig struct {
+	trustedProxies  []string
+	forwardLimit    int
+	headerAllowlist []string
+	onResolve       func(ip string, r *http.Request)
+}
+
+// WithTrustedProxies : Only trust X-Forwarded-For-style entries that came
+// through one of these CIDR ranges, walking the chain the same way
+// GetClientIPWithConfig does. Defaults to DefaultForwardLimit trusted hops;
+// pair with WithForwardLimit to change that. Composes with
+// WithHeaderAllowlist: the trusted walk is applied to whichever XFF-style
+// headers the allowlist permits; other allowlisted headers are not
+// consulted, and r.RemoteAddr is the fallback.
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(c *middlewareConfig) {
+		c.trustedProxies = cidrs
+	}
+}
+
+// WithForwardLimit : Set how many trusted hops WithTrustedProxies will skip
+// when walking X-Forwarded-For from right to left. Pass UnlimitedForwardLimit
+// to skip as many as match; has no effect without WithTrustedProxies.
+func WithForwardLimit(limit int) Option {
+	return func(c *middlewareConfig) {
+		c.forwardLimit = limit
+	}
+}
+
+// WithHeaderAllowlist : Restrict resolution to this set of headers instead
+// of the full default priority list, in the given order. Composes with
+// WithTrustedProxies; see its doc comment.
+func WithHeaderAllowlist(headers ...string) Option {
+	return func(c *middlewareConfig) {
+		c.headerAllowlist = headers
+	}
+}
+
+// OnResolve : Register a callback invoked with the resolved client IP and
+// the in-flight request, e.g. for request logging.
+func OnResolve(fn func(ip string, r *http.Request)) Option {
+	return func(c *middlewareConfig) {
+		c.onResolve = fn
+	}
+}
+
+// allowlistedResolver : Build a Resolver restricted to the given header
+// names, reusing each header's parsing strategy from the default priority
+// list when known and falling back to StrategySingleIP otherwise.
+func allowlistedResolver(names []string) Resolver {
+	strategies := make(map[string]HeaderStrategy, len(defaultResolver.Headers))
+	for _, h := range defaultResolver.Headers {
+		strategies[strings.ToLower(h.Name)] = h.Strategy
+	}
+
+	headers := make([]HeaderSpec, 0, len(names))
+	for _, name := range names {
+		strategy := strategies[strings.ToLower(name)]
+		headers = append(headers, HeaderSpec{Name: name, Strategy: strategy})
+	}
+
+	return Resolver{Headers: headers}
+}
+
+// resolveTrusted : Mirror GetClientIPWithConfig's trust model - if
+// RemoteAddr isn't itself a trusted proxy, headers aren't consulted at all,
+// since a direct, untrusted connection can set them to anything. Otherwise
+// walk whichever of resolver's headers are XFF-style with the same
+// right-to-left trusted-hop skipping, which is how WithHeaderAllowlist
+// composes with WithTrustedProxies: the allowlist picks which header
+// name(s) undergo the trusted walk instead of it being hard-coded to
+// X-Forwarded-For.
+func resolveTrusted(r *http.Request, resolver Resolver, cidrs []string, forwardLimit int) string {
+	trusted := parseTrustedProxies(cidrs)
+	remote := remoteAddrIP(r.RemoteAddr)
+
+	if !isTrustedProxy(remote, trusted) {
+		if isIP(remote) {
+			return remote
+		}
+		return ""
+	}
+
+	limit := resolvedForwardLimit(forwardLimit)
+	for _, h := range resolver.Headers {
+		if h.Strategy != StrategyXFF && h.Strategy != StrategyXFFRightmost {
+			continue
+		}
+		if ip := xffTrustedWalk(r.Header.Values(h.Name), trusted, limit); ip != "" {
+			return ip
+		}
+	}
+
+	if isIP(remote) {
+		return remote
+	}
+
+	return ""
+}
+
+// Middleware : Resolve the client IP once per request and rewrite
+// r.RemoteAddr to it (joined with port "0", since the resolved IP carries
+// no port), so downstream handlers, loggers and rate-limiters that
+// already read r.RemoteAddr transparently see the real client instead of
+// the last hop's address.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	cfg := &middlewareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	resolver := defaultResolver
+	if len(cfg.headerAllowlist) > 0 {
+		resolver = allowlistedResolver(cfg.headerAllowlist)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var ip string
+			if len(cfg.trustedProxies) > 0 {
+				ip = resolveTrusted(r, resolver, cfg.trustedProxies, cfg.forwardLimit)
+			} else {
+				ip = resolver.Resolve(r)
+			}
+
+			if ip != "" {
+				if cfg.onResolve != nil {
+					cfg.onResolve(ip, r)
+				}
+				r.RemoteAddr = net.JoinHostPort(ip, "0")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
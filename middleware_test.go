@@ -0,0 +1,89 @@
+package reqip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveMiddleware(t *testing.T, mw func(http.Handler) http.Handler, r *http.Request) string {
+	t.Helper()
+
+	var resolved string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved = r.RemoteAddr
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	return resolved
+}
+
+func TestMiddlewareRewritesRemoteAddr(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+
+	got := serveMiddleware(t, Middleware(), r)
+	if got != "8.8.8.8:0" {
+		t.Errorf("RemoteAddr = %q, want 8.8.8.8:0", got)
+	}
+}
+
+func TestMiddlewareOnResolveCallback(t *testing.T) {
+	var seen string
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+
+	serveMiddleware(t, Middleware(OnResolve(func(ip string, _ *http.Request) {
+		seen = ip
+	})), r)
+
+	if seen != "8.8.8.8" {
+		t.Errorf("OnResolve callback saw %q, want 8.8.8.8", seen)
+	}
+}
+
+func TestMiddlewareTrustedProxiesWithHeaderAllowlist(t *testing.T) {
+	// WithHeaderAllowlist must keep governing which header is walked even
+	// when WithTrustedProxies is also configured, instead of being dropped.
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.3:1234"
+	r.Header.Set("X-Forwarded-For", "9.9.9.9, 10.0.0.2, 10.0.0.3")
+
+	mw := Middleware(
+		WithTrustedProxies("10.0.0.0/8"),
+		WithHeaderAllowlist("x-forwarded-for"),
+	)
+
+	got := serveMiddleware(t, mw, r)
+	if got != "10.0.0.2:0" {
+		t.Errorf("RemoteAddr = %q, want 10.0.0.2:0", got)
+	}
+}
+
+func TestMiddlewareTrustedProxiesUntrustedRemote(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.9:1234"
+	r.Header.Set("X-Forwarded-For", "9.9.9.9")
+
+	mw := Middleware(WithTrustedProxies("10.0.0.0/8"))
+
+	got := serveMiddleware(t, mw, r)
+	if got != "203.0.113.9:0" {
+		t.Errorf("RemoteAddr = %q, want 203.0.113.9:0 (headers untrusted)", got)
+	}
+}
+
+func TestMiddlewareWithForwardLimit(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.3:1234"
+	r.Header.Set("X-Forwarded-For", "9.9.9.9, 10.0.0.2, 10.0.0.3")
+
+	mw := Middleware(
+		WithTrustedProxies("10.0.0.0/8"),
+		WithForwardLimit(UnlimitedForwardLimit),
+	)
+
+	got := serveMiddleware(t, mw, r)
+	if got != "9.9.9.9:0" {
+		t.Errorf("RemoteAddr = %q, want 9.9.9.9:0", got)
+	}
+}
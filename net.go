@@ -0,0 +1,115 @@
+package reqip
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Errors returned by GetClientIPNet, distinguishing why a client IP could
+// not be resolved.
+var (
+	// ErrNoHeaderMatched : None of the configured headers were present and
+	// r.RemoteAddr was empty, so there was nothing to parse at all.
+	ErrNoHeaderMatched = errors.New("reqip: no header matched a client IP")
+
+	// ErrInvalidHeader : A header reqip trusts was present but its value
+	// did not parse as an IP address.
+	ErrInvalidHeader = errors.New("reqip: header present but invalid")
+
+	// ErrRemoteAddrUnparseable : No usable header was found and
+	// r.RemoteAddr itself could not be parsed as an IP.
+	ErrRemoteAddrUnparseable = errors.New("reqip: RemoteAddr unparseable")
+)
+
+// parseIPPreservingZone : Parse s as an IP address, tolerating and retaining
+// an IPv6 zone suffix (e.g. "fe80::1%eth0") that net.ParseIP otherwise
+// rejects outright. net.IP has no field to carry a zone, so this returns a
+// net.IPAddr instead. The zero value's IP field is nil when s doesn't parse.
+func parseIPPreservingZone(s string) net.IPAddr {
+	if s == "" {
+		return net.IPAddr{}
+	}
+
+	host, zone := s, ""
+	if i := strings.IndexByte(s, '%'); i != -1 {
+		host, zone = s[:i], s[i+1:]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return net.IPAddr{}
+	}
+
+	return net.IPAddr{IP: ip, Zone: zone}
+}
+
+// GetClientIPNet : Like GetClientIP, but returns a parsed net.IPAddr instead
+// of a string, so callers can call .To4(), .IsPrivate(), .IsLoopback() etc.
+// on its IP field directly instead of re-parsing the string output, and
+// still see the original IPv6 zone via its Zone field. Unlike GetClientIP it
+// reports *why* resolution failed via ErrNoHeaderMatched, ErrInvalidHeader,
+// or ErrRemoteAddrUnparseable. Also unlike GetClientIP, it strips the port
+// from r.RemoteAddr before falling back to it, so "ip:port" still resolves.
+func GetClientIPNet(r *http.Request) (net.IPAddr, error) {
+	sawInvalidHeader := false
+
+	for _, h := range defaultResolver.Headers {
+		switch h.Strategy {
+		case StrategyXFF:
+			values := r.Header.Values(h.Name)
+			if len(values) == 0 {
+				continue
+			}
+			if addr := parseIPPreservingZone(getClientIPFromXForwardedFor(values)); addr.IP != nil {
+				return addr, nil
+			}
+			sawInvalidHeader = true
+
+		case StrategyForwarded:
+			values := r.Header.Values(h.Name)
+			if len(values) == 0 {
+				continue
+			}
+			if addr := parseIPPreservingZone(getClientIPFromForwarded(values)); addr.IP != nil {
+				return addr, nil
+			}
+			sawInvalidHeader = true
+
+		default:
+			raw := r.Header.Get(h.Name)
+			if raw == "" {
+				continue
+			}
+			if addr := parseIPPreservingZone(raw); addr.IP != nil {
+				return addr, nil
+			}
+			sawInvalidHeader = true
+		}
+	}
+
+	if addr := parseIPPreservingZone(remoteAddrIP(r.RemoteAddr)); addr.IP != nil {
+		return addr, nil
+	}
+
+	if sawInvalidHeader {
+		return net.IPAddr{}, ErrInvalidHeader
+	}
+	if r.RemoteAddr != "" {
+		return net.IPAddr{}, ErrRemoteAddrUnparseable
+	}
+	return net.IPAddr{}, ErrNoHeaderMatched
+}
+
+// GetClientIPPort : Return the source port from r.RemoteAddr, useful for
+// abuse tracking when the client IP alone isn't enough to identify a
+// connection. Returns ErrRemoteAddrUnparseable if RemoteAddr has no port.
+func GetClientIPPort(r *http.Request) (string, error) {
+	_, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "", ErrRemoteAddrUnparseable
+	}
+
+	return port, nil
+}
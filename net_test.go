@@ -0,0 +1,83 @@
+package reqip
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientIPNetPreservesZone(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+	r.Header.Set("X-Forwarded-For", "fe80::1%eth0")
+
+	addr, err := GetClientIPNet(r)
+	if err != nil {
+		t.Fatalf("GetClientIPNet() error = %v, want nil", err)
+	}
+	if addr.Zone != "eth0" {
+		t.Errorf("addr.Zone = %q, want eth0", addr.Zone)
+	}
+	if addr.IP.String() != "fe80::1" {
+		t.Errorf("addr.IP = %v, want fe80::1", addr.IP)
+	}
+}
+
+func TestGetClientIPNetInvalidHeader(t *testing.T) {
+	// RemoteAddr must also be unparseable, since a parseable RemoteAddr is a
+	// valid fallback even when a header was present but invalid.
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "not-a-valid-remote-addr"
+	r.Header.Set("X-Client-IP", "not-an-ip")
+
+	_, err := GetClientIPNet(r)
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("GetClientIPNet() error = %v, want ErrInvalidHeader", err)
+	}
+}
+
+func TestGetClientIPNetNoHeaderMatched(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = ""
+
+	_, err := GetClientIPNet(r)
+	if !errors.Is(err, ErrNoHeaderMatched) {
+		t.Errorf("GetClientIPNet() error = %v, want ErrNoHeaderMatched", err)
+	}
+}
+
+func TestGetClientIPNetRemoteAddrFallback(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+
+	addr, err := GetClientIPNet(r)
+	if err != nil {
+		t.Fatalf("GetClientIPNet() error = %v, want nil", err)
+	}
+	if addr.IP.String() != "198.51.100.1" {
+		t.Errorf("addr.IP = %v, want 198.51.100.1", addr.IP)
+	}
+}
+
+func TestGetClientIPPort(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "198.51.100.1:54321"
+
+	port, err := GetClientIPPort(r)
+	if err != nil {
+		t.Fatalf("GetClientIPPort() error = %v, want nil", err)
+	}
+	if port != "54321" {
+		t.Errorf("GetClientIPPort() = %q, want 54321", port)
+	}
+}
+
+func TestGetClientIPPortUnparseable(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "not-a-valid-remote-addr"
+
+	_, err := GetClientIPPort(r)
+	if !errors.Is(err, ErrRemoteAddrUnparseable) {
+		t.Errorf("GetClientIPPort() error = %v, want ErrRemoteAddrUnparseable", err)
+	}
+}
@@ -0,0 +1,110 @@
+package reqip
+
+import "net/http"
+
+// HeaderStrategy : How a header's value should be parsed into a client IP.
+type HeaderStrategy int
+
+const (
+	// StrategySingleIP treats the header value as a single IP address.
+	StrategySingleIP HeaderStrategy = iota
+	// StrategyXFF treats the header like X-Forwarded-For: a comma-separated
+	// list of IPs, read left-most first. The left-most entry is the
+	// originating client's own claim and is attacker-controlled unless
+	// every hop in front of it is trusted - prefer StrategyXFFRightmost for
+	// a header appended to by a single known proxy/load balancer.
+	StrategyXFF
+	// StrategyXFFRightmost treats the header like X-Forwarded-For but reads
+	// right-most first, i.e. the entry appended by whichever hop is closest
+	// to us. Appropriate for providers, like a load balancer, that append
+	// rather than replace the header.
+	StrategyXFFRightmost
+	// StrategyForwarded parses the header as RFC 7239 Forwarded syntax.
+	StrategyForwarded
+)
+
+// HeaderSpec : One entry in a Resolver's header priority list.
+type HeaderSpec struct {
+	Name     string
+	Strategy HeaderStrategy
+}
+
+// Resolver : Looks up a client IP by trying a configurable, ordered list of
+// headers before falling back to r.RemoteAddr. This replaces a hard-coded
+// if-ladder so callers behind a known provider can trust only that
+// provider's header instead of whichever spoofable header shows up first -
+// see the Cloudflare, AWSELB, GCPLoadBalancer, Fastly, Akamai and Nginx
+// presets.
+type Resolver struct {
+	Headers []HeaderSpec
+}
+
+// Resolve : Try each configured header in order and return the first IP
+// found, falling back to r.RemoteAddr.
+func (res Resolver) Resolve(r *http.Request) string {
+	for _, h := range res.Headers {
+		switch h.Strategy {
+		case StrategyXFF:
+			if ip := getClientIPFromXForwardedFor(r.Header.Values(h.Name)); isIP(ip) {
+				return ip
+			}
+		case StrategyXFFRightmost:
+			if ip := getClientIPFromXForwardedForRightmost(r.Header.Values(h.Name)); isIP(ip) {
+				return ip
+			}
+		case StrategyForwarded:
+			if ip := getClientIPFromForwarded(r.Header.Values(h.Name)); isIP(ip) {
+				return ip
+			}
+		default:
+			if ip := r.Header.Get(h.Name); isIP(ip) {
+				return ip
+			}
+		}
+	}
+
+	if ip := r.RemoteAddr; isIP(ip) {
+		return ip
+	}
+
+	return ""
+}
+
+// defaultResolver : Mirrors the header priority GetClientIP has always used.
+var defaultResolver = Resolver{
+	Headers: []HeaderSpec{
+		{"x-client-ip", StrategySingleIP},
+		{"x-forwarded-for", StrategyXFF},
+		{"cf-connecting-ip", StrategySingleIP},
+		{"fastly-client-ip", StrategySingleIP},
+		{"true-client-ip", StrategySingleIP},
+		{"x-real-ip", StrategySingleIP},
+		{"x-cluster-client-ip", StrategySingleIP},
+		{"x-forwarded", StrategySingleIP},
+		{"forwarded-for", StrategySingleIP},
+		{"forwarded", StrategyForwarded},
+	},
+}
+
+// Cloudflare : Trusts only CF-Connecting-IP.
+// @see https://support.cloudflare.com/hc/en-us/articles/200170986
+var Cloudflare = Resolver{Headers: []HeaderSpec{{"cf-connecting-ip", StrategySingleIP}}}
+
+// AWSELB : Trusts only X-Forwarded-For, reading the right-most entry since
+// that's the one Amazon's load balancer itself appended - the left-most
+// entry is the client's own unauthenticated claim.
+var AWSELB = Resolver{Headers: []HeaderSpec{{"x-forwarded-for", StrategyXFFRightmost}}}
+
+// GCPLoadBalancer : Trusts only X-Forwarded-For, reading the right-most
+// entry since that's the one Google Cloud's HTTP(S) load balancer itself
+// appended - the left-most entry is the client's own unauthenticated claim.
+var GCPLoadBalancer = Resolver{Headers: []HeaderSpec{{"x-forwarded-for", StrategyXFFRightmost}}}
+
+// Fastly : Trusts only Fastly-Client-IP.
+var Fastly = Resolver{Headers: []HeaderSpec{{"fastly-client-ip", StrategySingleIP}}}
+
+// Akamai : Trusts only True-Client-IP.
+var Akamai = Resolver{Headers: []HeaderSpec{{"true-client-ip", StrategySingleIP}}}
+
+// Nginx : Trusts only X-Real-IP, the header set by nginx's realip module.
+var Nginx = Resolver{Headers: []HeaderSpec{{"x-real-ip", StrategySingleIP}}}
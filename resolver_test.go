@@ -0,0 +1,45 @@
+package reqip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPresetsReadRightmostXFF(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.5")
+
+	for _, preset := range []struct {
+		name     string
+		resolver Resolver
+	}{
+		{"AWSELB", AWSELB},
+		{"GCPLoadBalancer", GCPLoadBalancer},
+	} {
+		t.Run(preset.name, func(t *testing.T) {
+			got := preset.resolver.Resolve(r)
+			if got != "10.0.0.5" {
+				t.Errorf("%s.Resolve() = %q, want 10.0.0.5 (right-most, not attacker-claimed left-most)", preset.name, got)
+			}
+		})
+	}
+}
+
+func TestPresetsTrustOnlyTheirHeader(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Client-IP", "6.6.6.6")
+	r.Header.Set("Cf-Connecting-Ip", "1.1.1.1")
+
+	if got := Cloudflare.Resolve(r); got != "1.1.1.1" {
+		t.Errorf("Cloudflare.Resolve() = %q, want 1.1.1.1", got)
+	}
+}
+
+func TestDefaultResolverMatchesGetClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+
+	if got := GetClientIP(r); got != "8.8.8.8" {
+		t.Errorf("GetClientIP() = %q, want 8.8.8.8", got)
+	}
+}